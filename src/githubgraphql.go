@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// githubGraphQLBatchSize is the number of repos queried per GraphQL request,
+// keeping individual queries well under GitHub's query cost limits.
+const githubGraphQLBatchSize = 50
+
+var (
+	githubTagCache   = map[string][]string{}
+	githubTagCacheMu sync.Mutex
+)
+
+type githubRepoRef struct {
+	Owner string
+	Repo  string
+}
+
+func githubRepoKey(ref githubRepoRef) string {
+	return ref.Owner + "/" + ref.Repo
+}
+
+/****************************************************/
+// githubRepoRefFromURL extracts the (owner, repo) pair from a GitHub URL,
+// for batching GraphQL lookups across many packages at once
+/****************************************************/
+func githubRepoRefFromURL(url string) (githubRepoRef, bool) {
+	re := regexp.MustCompile(`github.com/([^/]+)/([^/]+)/`)
+	matches := re.FindStringSubmatch(url)
+	if len(matches) < 3 {
+		return githubRepoRef{}, false
+	}
+	return githubRepoRef{Owner: matches[1], Repo: matches[2]}, true
+}
+
+/****************************************************/
+// prefetchGitHubTags collects tag lists for many GitHub repos in a handful
+// of GraphQL queries instead of one REST call per repo. GraphQL requires
+// authentication, so this is a no-op without a GitHub token — callers fall
+// back to the git-remote/REST path per repo in that case.
+/****************************************************/
+func prefetchGitHubTags(refs []githubRepoRef, token string) {
+	if token == "" || len(refs) == 0 {
+		return
+	}
+
+	// De-duplicate, since multiple packages may point at the same repo.
+	seen := map[string]githubRepoRef{}
+	for _, ref := range refs {
+		seen[githubRepoKey(ref)] = ref
+	}
+	unique := make([]githubRepoRef, 0, len(seen))
+	for _, ref := range seen {
+		unique = append(unique, ref)
+	}
+
+	for start := 0; start < len(unique); start += githubGraphQLBatchSize {
+		end := start + githubGraphQLBatchSize
+		if end > len(unique) {
+			end = len(unique)
+		}
+		batch := unique[start:end]
+
+		tags, err := fetchGitHubTagsGraphQL(batch, token)
+		if err != nil {
+			log.Printf("[WARN] GitHub GraphQL batch lookup failed, falling back to git-remote/REST for this batch: %v", err)
+			continue
+		}
+
+		githubTagCacheMu.Lock()
+		for key, tagNames := range tags {
+			githubTagCache[key] = tagNames
+		}
+		githubTagCacheMu.Unlock()
+	}
+}
+
+/****************************************************/
+// fetchGitHubTagsGraphQL issues a single GraphQL query requesting the last
+// 20 tag refs for each repo in batch, collapsing what would otherwise be
+// one REST call per repository into one HTTP request
+/****************************************************/
+func fetchGitHubTagsGraphQL(batch []githubRepoRef, token string) (map[string][]string, error) {
+	var query strings.Builder
+	query.WriteString("query {")
+	for i, ref := range batch {
+		fmt.Fprintf(&query, `r%d: repository(owner: %q, name: %q) { refs(refPrefix: "refs/tags/", last: 20, orderBy: {field: TAG_COMMIT_DATE, direction: DESC}) { nodes { name } } }`,
+			i, ref.Owner, ref.Repo)
+	}
+	query.WriteString("}")
+
+	payload, _ := json.Marshal(map[string]string{"query": query.String()})
+
+	var respBody []byte
+	err := doWithRetry("github-graphql", func() (*http.Response, error) {
+		if err := limiterForHost("api.github.com").Wait(context.Background()); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequest("POST", "https://api.github.com/graphql", bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			if body, readErr := io.ReadAll(resp.Body); readErr == nil {
+				respBody = body
+			}
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data map[string]*struct {
+			Refs struct {
+				Nodes []struct {
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"refs"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("GitHub GraphQL API error: %s", result.Errors[0].Message)
+	}
+
+	tags := map[string][]string{}
+	for i, ref := range batch {
+		alias := fmt.Sprintf("r%d", i)
+		entry, ok := result.Data[alias]
+		if !ok || entry == nil {
+			continue
+		}
+		names := make([]string, len(entry.Refs.Nodes))
+		for j, n := range entry.Refs.Nodes {
+			names[j] = n.Name
+		}
+		tags[githubRepoKey(ref)] = names
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+)
+
+var (
+	gitRemoteTagCache   = map[string][]string{}
+	gitRemoteTagCacheMu sync.Mutex
+)
+
+/****************************************************/
+// listRemoteTags lists tag names for a remote repository URL directly over
+// the git protocol, without hitting any forge's REST API. This mirrors Go
+// modules' move away from GitHub/Bitbucket APIs: no token is required and
+// it works against self-hosted Gitea/Forgejo/GitLab/Bitbucket/sourcehut the
+// same way it works against github.com. Results are cached per URL so
+// packages sharing an upstream reuse one listing; lookups of the same URL
+// that run concurrently before the first one finishes each list the remote.
+/****************************************************/
+func listRemoteTags(repoURL string) ([]string, error) {
+	gitRemoteTagCacheMu.Lock()
+	if tags, ok := gitRemoteTagCache[repoURL]; ok {
+		gitRemoteTagCacheMu.Unlock()
+		return tags, nil
+	}
+	gitRemoteTagCacheMu.Unlock()
+
+	remote := git.NewRemote(nil, &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{repoURL},
+	})
+
+	refs, err := remote.List(&git.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for _, ref := range refs {
+		if ref.Name().IsTag() {
+			tags = append(tags, ref.Name().Short())
+		}
+	}
+
+	gitRemoteTagCacheMu.Lock()
+	gitRemoteTagCache[repoURL] = tags
+	gitRemoteTagCacheMu.Unlock()
+
+	return tags, nil
+}
+
+/****************************************************/
+// fetchLatestTagFromRemote resolves the latest semver tag for repoURL by
+// listing tags directly from the git remote, bypassing forge REST APIs.
+// Candidates are filtered through pkg's tag filtering rules before the
+// semver sort, the same as the REST fallback path.
+/****************************************************/
+func fetchLatestTagFromRemote(repoURL string, pkg *Package) (string, error) {
+	tagNames, err := listRemoteTags(repoURL)
+	if err != nil {
+		return "", err
+	}
+	if len(tagNames) == 0 {
+		return "", fmt.Errorf("no tags found")
+	}
+
+	return selectLatestTag(tagNames, pkg)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// defaultHostRPS is the starting budget for a host we haven't heard rate
+// limit headers from yet. It's deliberately conservative; X-RateLimit-*
+// headers below tighten (or relax) it once a real response comes back.
+const defaultHostRPS = 2
+
+var (
+	hostLimiters   = map[string]*rate.Limiter{}
+	hostLimitersMu sync.Mutex
+
+	etagCache   = map[string]cachedResponse{}
+	etagCacheMu sync.Mutex
+)
+
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+/****************************************************/
+// limiterForHost returns the shared token-bucket limiter for a host,
+// creating one on first use so each API (GitHub, GitLab, Codeberg, ...)
+// gets its own independent budget
+/****************************************************/
+func limiterForHost(host string) *rate.Limiter {
+	hostLimitersMu.Lock()
+	defer hostLimitersMu.Unlock()
+
+	if l, ok := hostLimiters[host]; ok {
+		return l
+	}
+	l := rate.NewLimiter(rate.Limit(defaultHostRPS), 1)
+	hostLimiters[host] = l
+	return l
+}
+
+/****************************************************/
+// throttleFromHeaders narrows a host's limiter based on X-RateLimit-Remaining
+// and X-RateLimit-Reset: if the budget is exhausted, the bucket is paused
+// until the provider says it resets
+/****************************************************/
+func throttleFromHeaders(host string, header http.Header) {
+	remaining := header.Get("X-RateLimit-Remaining")
+	reset := header.Get("X-RateLimit-Reset")
+	if remaining == "" || reset == "" {
+		return
+	}
+
+	remainingN, err := strconv.Atoi(remaining)
+	if err != nil || remainingN > 0 {
+		return
+	}
+
+	resetUnix, err := strconv.ParseInt(reset, 10, 64)
+	if err != nil {
+		return
+	}
+
+	wait := time.Until(time.Unix(resetUnix, 0))
+	if wait <= 0 {
+		return
+	}
+
+	l := limiterForHost(host)
+	l.SetLimit(0)
+	time.AfterFunc(wait, func() {
+		l.SetLimit(rate.Limit(defaultHostRPS))
+	})
+}
+
+/****************************************************/
+// rateLimitedGet performs an HTTP GET against rawURL, waiting on that host's
+// token bucket first and sending If-None-Match from the ETag cache when one
+// is known. A 304 response returns the cached body without consuming any
+// extra provider quota.
+/****************************************************/
+func rateLimitedGet(rawURL string, setHeaders func(*http.Request)) ([]byte, int, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := limiterForHost(parsed.Host).Wait(context.Background()); err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if setHeaders != nil {
+		setHeaders(req)
+	}
+
+	etagCacheMu.Lock()
+	cached, hasCached := etagCache[rawURL]
+	etagCacheMu.Unlock()
+	if hasCached && cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	throttleFromHeaders(parsed.Host, resp.Header)
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached.body, http.StatusOK, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" && resp.StatusCode == http.StatusOK {
+		etagCacheMu.Lock()
+		etagCache[rawURL] = cachedResponse{etag: etag, body: body}
+		etagCacheMu.Unlock()
+	}
+
+	return body, resp.StatusCode, nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5"
@@ -11,14 +12,13 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 var (
 	RepoDir       = "./repo"
-	WebhookURL    string
 	GitHubToken   string
 	GitLabToken   string
 	CodebergToken string
@@ -30,7 +30,26 @@ type Package struct {
 	Version string `json:"version"`
 	Source  struct {
 		URL string `json:"url"`
+		// Type overrides auto-detection of the upstream source when the
+		// URL alone doesn't make it obvious (e.g. a custom mirror).
+		// One of: github, gitlab, codeberg, pypi, npm, crates, hackage,
+		// docker, gnuftp, sourceforge.
+		Type string `json:"type"`
 	} `json:"source"`
+
+	// TagPattern, when set, restricts candidate tags to those matching this
+	// regex; if the regex has a capturing group, group 1 is used as the
+	// version instead of the whole tag name (e.g. "^app-v(\d+\.\d+\.\d+)$").
+	TagPattern string `json:"tagPattern"`
+	// IncludePrerelease allows semver prerelease tags (e.g. "1.2.0-rc1") to
+	// be considered the latest version. Defaults to false.
+	IncludePrerelease bool `json:"includePrerelease"`
+	// StripPrefix is trimmed from the front of a matched tag before it's
+	// parsed as a version (e.g. "v", "release-").
+	StripPrefix string `json:"stripPrefix"`
+	// VersionTransform is a sed-style "s/pattern/replacement/" applied to a
+	// matched tag before it's parsed as a version.
+	VersionTransform string `json:"versionTransform"`
 }
 
 type Update struct {
@@ -81,76 +100,37 @@ func getRepo() {
 }
 
 /****************************************************/
-// sendDiscord sends a message to a Discord webhook
-// splits messages into chunks under 1900 characters to prevent truncation
+// fetchLatestTag retrieves the latest semver tag from a repository API,
+// supporting GitHub, GitLab, and Codeberg with optional authentication
+// tokens. Requests go through the shared per-host rate limiter and ETag
+// cache so repeated calls to the same API don't burn through its rate limit
+// budget. Candidate tags are filtered through pkg's TagPattern/StripPrefix/
+// VersionTransform/IncludePrerelease rules before the semver sort.
 /****************************************************/
-func sendDiscord(content string) {
-	const maxLen = 1900
-	log.Printf("[DEBUG] Sending message to Discord, length=%d", len(content))
-
-	for len(content) > 0 {
-		chunk := content
-		if len(chunk) > maxLen {
-			chunk = chunk[:maxLen]
-			lastNewline := strings.LastIndex(chunk, "\n")
-			if lastNewline > 0 {
-				chunk = chunk[:lastNewline]
-			}
+func fetchLatestTag(apiURL string, token string, provider string, pkg *Package) (string, error) {
+	body, status, err := rateLimitedGet(apiURL, func(req *http.Request) {
+		if provider == "github" && token != "" {
+			req.Header.Set("Authorization", "token "+token)
 		}
-
-		chunk = strings.TrimSpace(chunk)
-		if chunk == "" {
-			content = content[len(chunk):]
-			content = strings.TrimLeft(content, "\n")
-			continue
+		if provider == "gitlab" && token != "" {
+			req.Header.Set("PRIVATE-TOKEN", token)
 		}
-
-		payload := map[string]string{"content": chunk}
-		body, _ := json.Marshal(payload)
-
-		resp, err := http.Post(WebhookURL, "application/json", strings.NewReader(string(body)))
-		if err != nil {
-			log.Printf("[ERROR] Discord POST error: %v", err)
-		} else {
-			log.Printf("[DEBUG] Discord POST success, status %d", resp.StatusCode)
-			resp.Body.Close()
+		if provider == "codeberg" && token != "" {
+			req.Header.Set("Authorization", "token "+token)
 		}
-
-		content = content[len(chunk):]
-		content = strings.TrimLeft(content, "\n")
-	}
-}
-
-/****************************************************/
-// fetchLatestTag retrieves the latest semver tag from a repository API
-// supports GitHub, GitLab, and Codeberg with optional authentication tokens
-/****************************************************/
-func fetchLatestTag(apiURL string, token string, provider string) (string, error) {
-	req, _ := http.NewRequest("GET", apiURL, nil)
-	if provider == "github" && token != "" {
-		req.Header.Set("Authorization", "token "+token)
-	}
-	if provider == "gitlab" && token != "" {
-		req.Header.Set("PRIVATE-TOKEN", token)
-	}
-	if provider == "codeberg" && token != "" {
-		req.Header.Set("Authorization", "token "+token)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
+	})
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("API returned status %d", resp.StatusCode)
+	if status != 200 {
+		return "", fmt.Errorf("API returned status %d", status)
 	}
 
 	var tags []struct {
 		Name string `json:"name"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+	if err := json.Unmarshal(body, &tags); err != nil {
 		return "", err
 	}
 
@@ -158,66 +138,93 @@ func fetchLatestTag(apiURL string, token string, provider string) (string, error
 		return "", fmt.Errorf("no tags found")
 	}
 
-	versions := []*semver.Version{}
-	for _, t := range tags {
-		v, err := semver.NewVersion(t.Name)
-		if err != nil {
-			continue
-		}
-		versions = append(versions, v)
+	tagNames := make([]string, len(tags))
+	for i, t := range tags {
+		tagNames[i] = t.Name
 	}
-
-	if len(versions) == 0 {
-		return "", fmt.Errorf("no valid semver tags found")
-	}
-
-	sort.Sort(sort.Reverse(semver.Collection(versions)))
-	return versions[0].String(), nil
+	return selectLatestTag(tagNames, pkg)
 }
 
 /****************************************************/
-// getGitHubLatestTag extracts repository info from a GitHub URL
-// calls fetchLatestTag for GitHub API
+// getGitHubLatestTag extracts repository info from a GitHub URL. It prefers
+// a batch-prefetched GraphQL tag list (see prefetchGitHubTags), then a
+// direct git-remote tag listing, then finally the REST API
 /****************************************************/
-func getGitHubLatestTag(url string) (string, error) {
+func getGitHubLatestTag(pkg *Package) (string, error) {
 	re := regexp.MustCompile(`github.com/([^/]+)/([^/]+)/`)
-	matches := re.FindStringSubmatch(url)
+	matches := re.FindStringSubmatch(pkg.Source.URL)
 	if len(matches) < 3 {
-		return "", fmt.Errorf("invalid GitHub URL: %s", url)
+		return "", fmt.Errorf("invalid GitHub URL: %s", pkg.Source.URL)
 	}
 	user, repo := matches[1], matches[2]
+
+	githubTagCacheMu.Lock()
+	cachedTags, ok := githubTagCache[githubRepoKey(githubRepoRef{Owner: user, Repo: repo})]
+	githubTagCacheMu.Unlock()
+	if ok {
+		if latest, err := selectLatestTag(cachedTags, pkg); err == nil {
+			return latest, nil
+		} else {
+			log.Printf("[WARN] prefetched GraphQL tags for %s/%s didn't match, falling back to git-remote/REST: %v", user, repo, err)
+		}
+	}
+
+	gitURL := fmt.Sprintf("https://github.com/%s/%s.git", user, repo)
+	if latest, err := fetchLatestTagFromRemote(gitURL, pkg); err == nil {
+		return latest, nil
+	} else {
+		log.Printf("[WARN] git-remote tag lookup failed for %s, falling back to REST API: %v", gitURL, err)
+	}
+
 	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/tags", user, repo)
-	return fetchLatestTag(apiURL, GitHubToken, "github")
+	return fetchLatestTag(apiURL, GitHubToken, "github", pkg)
 }
 
 /****************************************************/
 // getGitLabLatestTag extracts project info from a GitLab URL
-// calls fetchLatestTag for GitLab API
+// prefers a direct git-remote tag listing, falling back to the REST API
 /****************************************************/
-func getGitLabLatestTag(url string) (string, error) {
+func getGitLabLatestTag(pkg *Package) (string, error) {
 	re := regexp.MustCompile(`gitlab.com/([^/]+/[^/]+)/`)
-	matches := re.FindStringSubmatch(url)
+	matches := re.FindStringSubmatch(pkg.Source.URL)
 	if len(matches) < 2 {
-		return "", fmt.Errorf("invalid GitLab URL: %s", url)
+		return "", fmt.Errorf("invalid GitLab URL: %s", pkg.Source.URL)
 	}
-	project := strings.ReplaceAll(matches[1], "/", "%2F")
-	apiURL := fmt.Sprintf("https://gitlab.com/api/v4/projects/%s/repository/tags", project)
-	return fetchLatestTag(apiURL, GitLabToken, "gitlab")
+	project := matches[1]
+
+	gitURL := fmt.Sprintf("https://gitlab.com/%s.git", project)
+	if latest, err := fetchLatestTagFromRemote(gitURL, pkg); err == nil {
+		return latest, nil
+	} else {
+		log.Printf("[WARN] git-remote tag lookup failed for %s, falling back to REST API: %v", gitURL, err)
+	}
+
+	encodedProject := strings.ReplaceAll(project, "/", "%2F")
+	apiURL := fmt.Sprintf("https://gitlab.com/api/v4/projects/%s/repository/tags", encodedProject)
+	return fetchLatestTag(apiURL, GitLabToken, "gitlab", pkg)
 }
 
 /****************************************************/
 // getCodebergLatestTag extracts repository info from a Codeberg URL
-// calls fetchLatestTag for Codeberg API
+// prefers a direct git-remote tag listing, falling back to the REST API
 /****************************************************/
-func getCodebergLatestTag(url string) (string, error) {
+func getCodebergLatestTag(pkg *Package) (string, error) {
 	re := regexp.MustCompile(`codeberg.org/([^/]+)/([^/]+)/`)
-	matches := re.FindStringSubmatch(url)
+	matches := re.FindStringSubmatch(pkg.Source.URL)
 	if len(matches) < 3 {
-		return "", fmt.Errorf("invalid Codeberg URL: %s", url)
+		return "", fmt.Errorf("invalid Codeberg URL: %s", pkg.Source.URL)
 	}
 	user, repo := matches[1], matches[2]
+
+	gitURL := fmt.Sprintf("https://codeberg.org/%s/%s.git", user, repo)
+	if latest, err := fetchLatestTagFromRemote(gitURL, pkg); err == nil {
+		return latest, nil
+	} else {
+		log.Printf("[WARN] git-remote tag lookup failed for %s, falling back to REST API: %v", gitURL, err)
+	}
+
 	apiURL := fmt.Sprintf("https://codeberg.org/api/v1/repos/%s/%s/tags", user, repo)
-	return fetchLatestTag(apiURL, CodebergToken, "codeberg")
+	return fetchLatestTag(apiURL, CodebergToken, "codeberg", pkg)
 }
 
 /****************************************************/
@@ -246,84 +253,246 @@ func parseJSONFile(path string) (*Package, error) {
 	return &pkg, nil
 }
 
+/****************************************************/
+// collectPackagePaths walks root and returns the path of every package JSON
+// file found, without doing any network work
+/****************************************************/
+func collectPackagePaths(root string) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	return paths, err
+}
+
+/****************************************************/
+// collectGitHubRepoRefs scans package JSON files for ones whose source
+// resolves to GitHub, returning the (owner, repo) pairs so their tags can
+// be prefetched in a handful of GraphQL batches instead of one call each
+/****************************************************/
+func collectGitHubRepoRefs(paths []string) []githubRepoRef {
+	var refs []githubRepoRef
+	for _, path := range paths {
+		pkg, err := parseJSONFile(path)
+		if err != nil || pkg.Source.URL == "" {
+			continue
+		}
+
+		sourceType := pkg.Source.Type
+		if sourceType == "" {
+			sourceType = detectSourceType(pkg.Source.URL)
+		}
+		if sourceType != "github" {
+			continue
+		}
+
+		if ref, ok := githubRepoRefFromURL(pkg.Source.URL); ok {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+/****************************************************/
+// processPackage resolves the latest upstream version for the package JSON
+// at path, updates state, and returns the Update to notify about, or nil if
+// nothing changed
+/****************************************************/
+func processPackage(path string, state *StateStore) *Update {
+	pkg, err := parseJSONFile(path)
+	if err != nil || pkg.Source.URL == "" {
+		return nil
+	}
+
+	sourceType := pkg.Source.Type
+	if sourceType == "" {
+		sourceType = detectSourceType(pkg.Source.URL)
+	}
+
+	var latest string
+	switch sourceType {
+	case "github":
+		latest, err = getGitHubLatestTag(pkg)
+	case "gitlab":
+		latest, err = getGitLabLatestTag(pkg)
+	case "codeberg":
+		latest, err = getCodebergLatestTag(pkg)
+	case "pypi":
+		latest, err = getPyPILatestVersion(pkg.Source.URL)
+	case "npm":
+		latest, err = getNpmLatestVersion(pkg.Source.URL)
+	case "crates":
+		latest, err = getCratesLatestVersion(pkg.Source.URL)
+	case "hackage":
+		latest, err = getHackageLatestVersion(pkg.Source.URL)
+	case "docker":
+		latest, err = getDockerLatestTag(pkg.Source.URL)
+	case "gnuftp":
+		latest, err = getGNUFTPLatestVersion(pkg.Source.URL)
+	case "sourceforge":
+		latest, err = getSourceForgeLatestVersion(pkg.Source.URL)
+	default:
+		latest = getVersionFromURL(pkg.Source.URL)
+	}
+
+	relPath, _ := filepath.Rel(RepoDir, filepath.Dir(path))
+	parts := strings.Split(relPath, string(os.PathSeparator))
+	repoName := parts[0]
+
+	if err != nil {
+		log.Printf("[ERROR] Failed to get latest version for %s: %v", pkg.Name, err)
+		entry, _ := state.get(repoName, pkg.Name)
+		entry.FailureCount++
+		state.set(repoName, pkg.Name, entry)
+		return nil
+	}
+
+	entry, existed := state.get(repoName, pkg.Name)
+	entry.FailureCount = 0
+
+	currentVer, err1 := semver.NewVersion(pkg.Version)
+	latestVer, err2 := semver.NewVersion(latest)
+	warning := false
+	if err1 == nil && err2 == nil && currentVer.GreaterThan(latestVer) {
+		warning = true
+		log.Printf("[WARN] Package retroceded: %s %s → %s", pkg.Name, pkg.Version, latest)
+	} else if err1 != nil || err2 != nil || currentVer.Equal(latestVer) {
+		if !existed || entry.LastSeenVersion != latest {
+			entry.LastChangedAt = time.Now()
+		}
+		entry.LastSeenVersion = latest
+		entry.Warned = false
+		state.set(repoName, pkg.Name, entry)
+		return nil
+	}
+
+	// Only notify the first time this version (or retroceded state) is
+	// seen; subsequent runs with no change stay silent.
+	changed := !existed || entry.LastSeenVersion != latest
+	alreadyWarned := warning && existed && entry.Warned
+	shouldNotify := changed || (warning && !alreadyWarned)
+
+	if changed {
+		entry.LastChangedAt = time.Now()
+	}
+	entry.LastSeenVersion = latest
+	entry.Warned = warning
+	if shouldNotify {
+		entry.LastNotifiedAt = time.Now()
+	}
+	state.set(repoName, pkg.Name, entry)
+
+	if !shouldNotify {
+		return nil
+	}
+
+	return &Update{
+		RepoName: repoName,
+		PkgName:  pkg.Name,
+		Current:  pkg.Version,
+		Latest:   latest,
+		JsonPath: path,
+		Warning:  warning,
+	}
+}
+
+/****************************************************/
+// scanPackages fans package paths out across a pool of concurrency workers,
+// each pulling from a shared channel, and collects the resulting updates.
+// Per-host rate limiting happens lower down in rateLimitedGet, so workers
+// don't need their own throttling.
+/****************************************************/
+func scanPackages(paths []string, state *StateStore, concurrency int) []Update {
+	jobs := make(chan string)
+	results := make(chan Update)
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				if u := processPackage(path, state); u != nil {
+					results <- *u
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for _, p := range paths {
+			jobs <- p
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var updates []Update
+	for u := range results {
+		updates = append(updates, u)
+	}
+	return updates
+}
+
 // main function is the core function, self explanatory
 func main() {
+	concurrency := flag.Int("concurrency", 8, "number of packages to scan concurrently")
+	reportUnchangedDays := flag.Int("report-unchanged-days", 0, "if set, also report packages whose version hasn't changed in at least this many days")
+	flag.Parse()
+
 	// Load environment
 	_ = godotenv.Load()
-	WebhookURL = os.Getenv("WEBHOOK_URL")
 	GitHubToken = os.Getenv("GITHUB_TOKEN")
 	GitLabToken = os.Getenv("GITLAB_TOKEN")
 	CodebergToken = os.Getenv("CODEBERG_TOKEN")
 
-	if WebhookURL == "" {
-		log.Fatal("[ERROR] WEBHOOK_URL not set")
+	notifiers := buildNotifiers()
+	if len(notifiers) == 0 {
+		log.Fatal("[ERROR] no notification backend configured")
+	}
+
+	state, err := loadStateStore(stateFilePath)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to load state file: %v", err)
 	}
 
 	// Clone repo
 	getRepo()
 
-	var updates []Update
-	filepath.Walk(RepoDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
-			return nil
-		}
-
-		pkg, err := parseJSONFile(path)
-		if err != nil || pkg.Source.URL == "" {
-			return nil
-		}
-
-		var latest string
-		switch {
-		case strings.Contains(pkg.Source.URL, "github.com"):
-			latest, err = getGitHubLatestTag(pkg.Source.URL)
-		case strings.Contains(pkg.Source.URL, "gitlab.com"):
-			latest, err = getGitLabLatestTag(pkg.Source.URL)
-		case strings.Contains(pkg.Source.URL, "codeberg.org"):
-			latest, err = getCodebergLatestTag(pkg.Source.URL)
-		default:
-			latest = getVersionFromURL(pkg.Source.URL)
-		}
+	paths, err := collectPackagePaths(RepoDir)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to walk repo: %v", err)
+	}
 
-		if err != nil {
-			log.Printf("[ERROR] Failed to get latest version for %s: %v", pkg.Name, err)
-			return nil
-		}
+	prefetchGitHubTags(collectGitHubRepoRefs(paths), GitHubToken)
 
-		currentVer, err1 := semver.NewVersion(pkg.Version)
-		latestVer, err2 := semver.NewVersion(latest)
-		warning := false
-		if err1 == nil && err2 == nil && currentVer.GreaterThan(latestVer) {
-			warning = true
-			log.Printf("[WARN] Package retroceded: %s %s → %s", pkg.Name, pkg.Version, latest)
-		} else if err1 != nil || err2 != nil || currentVer.Equal(latestVer) {
-			return nil
-		}
+	updates := scanPackages(paths, state, *concurrency)
 
-		relPath, _ := filepath.Rel(RepoDir, filepath.Dir(path))
-		parts := strings.Split(relPath, string(os.PathSeparator))
-		repoName := parts[0]
-
-		updates = append(updates, Update{
-			RepoName: repoName,
-			PkgName:  pkg.Name,
-			Current:  pkg.Version,
-			Latest:   latest,
-			JsonPath: path,
-			Warning:  warning,
-		})
-
-		// Small delay to respect rate limits (~1 req/sec)
-		time.Sleep(800 * time.Millisecond)
-		return nil
-	})
+	if err := state.save(); err != nil {
+		log.Printf("[ERROR] Failed to save state file: %v", err)
+	}
 
 	// Send header
 	date := time.Now().Format("02 January 2006")
-	sendDiscord(fmt.Sprintf("||<@&1417420496655482930>||\n# Repository Checklist [%s]", date))
+	notify(notifiers, fmt.Sprintf("||<@&1417420496655482930>||\n# Repository Checklist [%s]", date))
+
+	if *reportUnchangedDays > 0 {
+		if lines := state.unchangedReport(*reportUnchangedDays); len(lines) > 0 {
+			msg := fmt.Sprintf("## Unchanged for %d+ days\n```%s```", *reportUnchangedDays, strings.Join(lines, "\n"))
+			notify(notifiers, msg)
+		}
+	}
 
 	if len(updates) == 0 {
-		sendDiscord("No new versions found.")
+		notify(notifiers, "No new versions found.")
 		clean()
 		return
 	}
@@ -334,7 +503,7 @@ func main() {
 			msg += "# <:warn:1428846936219324476> - ! PACKAGE RETROCEDED ! "
 		}
 		msg += fmt.Sprintf("```- %s/%s %s → %s```", u.RepoName, u.PkgName, u.Current, u.Latest)
-		sendDiscord(msg)
+		notify(notifiers, msg)
 	}
 
 	clean()
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notifier is implemented by every notification backend. Send delivers one
+// logical message, applying whatever chunking rules that backend requires.
+type Notifier interface {
+	Name() string
+	Send(content string) error
+}
+
+const (
+	maxRetries     = 5
+	initialBackoff = 1 * time.Second
+)
+
+/****************************************************/
+// doWithRetry executes req, retrying transient failures with exponential
+// backoff and honoring HTTP 429 Retry-After before giving up.
+/****************************************************/
+func doWithRetry(name string, req func() (*http.Response, error)) error {
+	backoff := initialBackoff
+	var lastErr error
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		resp, err := req()
+		if err != nil {
+			lastErr = err
+			log.Printf("[ERROR] %s request error (attempt %d/%d): %v", name, attempt+1, maxRetries, err)
+			time.Sleep(backoff)
+			backoff *= 2
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			wait := backoff
+			if ra := resp.Header.Get("Retry-After"); ra != "" {
+				if secs, err := strconv.Atoi(ra); err == nil {
+					wait = time.Duration(secs) * time.Second
+				}
+			}
+			resp.Body.Close()
+			log.Printf("[WARN] %s rate limited, waiting %s (attempt %d/%d)", name, wait, attempt+1, maxRetries)
+			time.Sleep(wait)
+			backoff *= 2
+			continue
+		}
+
+		if resp.StatusCode >= 500 {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("%s returned status %d", name, resp.StatusCode)
+			time.Sleep(backoff)
+			backoff *= 2
+			continue
+		}
+
+		if resp.StatusCode >= 400 {
+			resp.Body.Close()
+			return fmt.Errorf("%s returned status %d", name, resp.StatusCode)
+		}
+
+		resp.Body.Close()
+		return nil
+	}
+
+	return fmt.Errorf("%s failed after %d attempts: %w", name, maxRetries, lastErr)
+}
+
+/****************************************************/
+// chunkMessage splits content into pieces no longer than maxLen, breaking on
+// the last newline before the limit so whole lines stay together. A single
+// line longer than maxLen is cut at maxLen, which can split a word.
+/****************************************************/
+func chunkMessage(content string, maxLen int) []string {
+	var chunks []string
+	for len(content) > 0 {
+		chunk := content
+		if len(chunk) > maxLen {
+			chunk = chunk[:maxLen]
+			if i := strings.LastIndex(chunk, "\n"); i > 0 {
+				chunk = chunk[:i]
+			}
+		}
+
+		rawLen := len(chunk)
+		chunk = strings.TrimSpace(chunk)
+		content = strings.TrimLeft(content[rawLen:], "\n")
+		if chunk == "" {
+			continue
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+/****************************************************/
+// DiscordNotifier sends messages to a Discord webhook, splitting content
+// into chunks under Discord's message length limit
+/****************************************************/
+type DiscordNotifier struct {
+	WebhookURL string
+}
+
+func (d *DiscordNotifier) Name() string { return "discord" }
+
+func (d *DiscordNotifier) Send(content string) error {
+	const maxLen = 1900
+	var errs []string
+	for _, chunk := range chunkMessage(content, maxLen) {
+		payload := map[string]string{"content": chunk}
+		body, _ := json.Marshal(payload)
+		err := doWithRetry("discord", func() (*http.Response, error) {
+			return http.Post(d.WebhookURL, "application/json", bytes.NewReader(body))
+		})
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("discord: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+/****************************************************/
+// SlackNotifier sends messages to a Slack incoming webhook, splitting
+// content into chunks under Slack's ~3000 character message limit
+/****************************************************/
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+func (s *SlackNotifier) Name() string { return "slack" }
+
+func (s *SlackNotifier) Send(content string) error {
+	const maxLen = 3000
+	var errs []string
+	for _, chunk := range chunkMessage(content, maxLen) {
+		payload := map[string]string{"text": chunk}
+		body, _ := json.Marshal(payload)
+		err := doWithRetry("slack", func() (*http.Response, error) {
+			return http.Post(s.WebhookURL, "application/json", bytes.NewReader(body))
+		})
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("slack: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+/****************************************************/
+// TelegramNotifier sends messages via the Telegram Bot API, splitting
+// content into chunks under Telegram's 4096 character message limit
+/****************************************************/
+type TelegramNotifier struct {
+	BotToken string
+	ChatID   string
+}
+
+func (t *TelegramNotifier) Name() string { return "telegram" }
+
+func (t *TelegramNotifier) Send(content string) error {
+	const maxLen = 4096
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.BotToken)
+
+	var errs []string
+	for _, chunk := range chunkMessage(content, maxLen) {
+		payload := map[string]string{"chat_id": t.ChatID, "text": chunk}
+		body, _ := json.Marshal(payload)
+		err := doWithRetry("telegram", func() (*http.Response, error) {
+			return http.Post(apiURL, "application/json", bytes.NewReader(body))
+		})
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("telegram: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+/****************************************************/
+// MatrixNotifier posts HTML-formatted messages to a Matrix room via the
+// client-server API's send endpoint
+/****************************************************/
+type MatrixNotifier struct {
+	HomeserverURL string
+	AccessToken   string
+	RoomID        string
+
+	txnCounter int64
+}
+
+func (m *MatrixNotifier) Name() string { return "matrix" }
+
+func (m *MatrixNotifier) Send(content string) error {
+	const maxLen = 4000
+	var errs []string
+	for _, chunk := range chunkMessage(content, maxLen) {
+		html := strings.ReplaceAll(chunk, "\n", "<br>")
+		payload := map[string]string{
+			"msgtype":        "m.text",
+			"body":           chunk,
+			"format":         "org.matrix.custom.html",
+			"formatted_body": html,
+		}
+		body, _ := json.Marshal(payload)
+
+		if m.txnCounter == 0 {
+			// Seed from the clock so txnIds don't collide with a previous
+			// run's against the same access token: Synapse and other
+			// homeservers dedupe sends on (access_token, txnId).
+			m.txnCounter = time.Now().UnixNano()
+		}
+		m.txnCounter++
+		sendURL := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%d",
+			strings.TrimRight(m.HomeserverURL, "/"), m.RoomID, m.txnCounter)
+
+		err := doWithRetry("matrix", func() (*http.Response, error) {
+			req, reqErr := http.NewRequest(http.MethodPut, sendURL, bytes.NewReader(body))
+			if reqErr != nil {
+				return nil, reqErr
+			}
+			req.Header.Set("Authorization", "Bearer "+m.AccessToken)
+			req.Header.Set("Content-Type", "application/json")
+			return http.DefaultClient.Do(req)
+		})
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("matrix: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+/****************************************************/
+// EmailNotifier sends updates as plain-text email via SMTP. Email has no
+// practical length limit, so messages are sent whole rather than chunked
+/****************************************************/
+type EmailNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (e *EmailNotifier) Name() string { return "email" }
+
+func (e *EmailNotifier) Send(content string) error {
+	addr := fmt.Sprintf("%s:%s", e.Host, e.Port)
+	auth := smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: Blink Notifier Update\r\n\r\n%s",
+		e.From, strings.Join(e.To, ", "), content)
+
+	backoff := initialBackoff
+	var lastErr error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if err := smtp.SendMail(addr, auth, e.From, e.To, []byte(msg)); err != nil {
+			lastErr = err
+			log.Printf("[ERROR] email send error (attempt %d/%d): %v", attempt+1, maxRetries, err)
+			time.Sleep(backoff)
+			backoff *= 2
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("email: failed after %d attempts: %w", maxRetries, lastErr)
+}
+
+/****************************************************/
+// GenericWebhookNotifier posts a raw JSON payload to an arbitrary HTTP
+// endpoint, for integrations that don't need backend-specific formatting
+/****************************************************/
+type GenericWebhookNotifier struct {
+	URL string
+}
+
+func (g *GenericWebhookNotifier) Name() string { return "webhook" }
+
+func (g *GenericWebhookNotifier) Send(content string) error {
+	payload := map[string]string{"content": content}
+	body, _ := json.Marshal(payload)
+	return doWithRetry("webhook", func() (*http.Response, error) {
+		return http.Post(g.URL, "application/json", bytes.NewReader(body))
+	})
+}
+
+/****************************************************/
+// buildNotifiers constructs the set of configured Notifier backends from
+// environment variables
+/****************************************************/
+func buildNotifiers() []Notifier {
+	var notifiers []Notifier
+
+	if url := os.Getenv("DISCORD_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, &DiscordNotifier{WebhookURL: url})
+	}
+	if url := os.Getenv("SLACK_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, &SlackNotifier{WebhookURL: url})
+	}
+	if token, chatID := os.Getenv("TELEGRAM_BOT_TOKEN"), os.Getenv("TELEGRAM_CHAT_ID"); token != "" && chatID != "" {
+		notifiers = append(notifiers, &TelegramNotifier{BotToken: token, ChatID: chatID})
+	}
+	if hs, token, room := os.Getenv("MATRIX_HOMESERVER_URL"), os.Getenv("MATRIX_ACCESS_TOKEN"), os.Getenv("MATRIX_ROOM_ID"); hs != "" && token != "" && room != "" {
+		notifiers = append(notifiers, &MatrixNotifier{HomeserverURL: hs, AccessToken: token, RoomID: room})
+	}
+	if host, from, to := os.Getenv("SMTP_HOST"), os.Getenv("SMTP_FROM"), os.Getenv("SMTP_TO"); host != "" && from != "" && to != "" {
+		notifiers = append(notifiers, &EmailNotifier{
+			Host:     host,
+			Port:     envOrDefault("SMTP_PORT", "587"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+			To:       strings.Split(to, ","),
+		})
+	}
+	if url := os.Getenv("GENERIC_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, &GenericWebhookNotifier{URL: url})
+	}
+
+	// WEBHOOK_URL alone still means Discord, for existing deployments.
+	if len(notifiers) == 0 {
+		if url := os.Getenv("WEBHOOK_URL"); url != "" {
+			notifiers = append(notifiers, &DiscordNotifier{WebhookURL: url})
+		}
+	}
+
+	return notifiers
+}
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+/****************************************************/
+// notify fans a message out to every configured notifier concurrently and
+// aggregates any errors instead of failing on the first backend
+/****************************************************/
+func notify(notifiers []Notifier, content string) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []string
+
+	for _, n := range notifiers {
+		wg.Add(1)
+		go func(n Notifier) {
+			defer wg.Done()
+			if err := n.Send(content); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %v", n.Name(), err))
+				mu.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		log.Printf("[ERROR] notification delivery failures: %s", strings.Join(errs, "; "))
+	}
+}
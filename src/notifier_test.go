@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChunkMessageTerminates(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		maxLen  int
+	}{
+		{"empty", "", 10},
+		{"shorter than limit", "hello", 10},
+		{"exact boundary on whitespace", "a   ", 1},
+		{"trailing spaces past limit", "aaaaa     bbbbb", 5},
+		{"only whitespace", "     ", 3},
+		{"newline heavy", "a\n\n\n\nb", 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			done := make(chan []string, 1)
+			go func() { done <- chunkMessage(tc.content, tc.maxLen) }()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatalf("chunkMessage(%q, %d) did not terminate", tc.content, tc.maxLen)
+			}
+		})
+	}
+}
+
+func TestChunkMessageContent(t *testing.T) {
+	chunks := chunkMessage("line one\nline two\nline three", 12)
+	if len(chunks) == 0 {
+		t.Fatalf("expected at least one chunk")
+	}
+	for _, c := range chunks {
+		if strings.TrimSpace(c) == "" {
+			t.Fatalf("got an empty chunk in result: %q", chunks)
+		}
+	}
+	if got := strings.Join(chunks, "\n"); !strings.Contains(got, "line one") || !strings.Contains(got, "line three") {
+		t.Fatalf("chunks lost content: %q", chunks)
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+/****************************************************/
+// detectSourceType infers a Package.Source.Type from its URL when the JSON
+// doesn't set one explicitly
+/****************************************************/
+func detectSourceType(url string) string {
+	switch {
+	case strings.Contains(url, "github.com"):
+		return "github"
+	case strings.Contains(url, "gitlab.com"):
+		return "gitlab"
+	case strings.Contains(url, "codeberg.org"):
+		return "codeberg"
+	case strings.Contains(url, "pypi.org"):
+		return "pypi"
+	case strings.Contains(url, "npmjs.com") || strings.Contains(url, "registry.npmjs.org"):
+		return "npm"
+	case strings.Contains(url, "crates.io"):
+		return "crates"
+	case strings.Contains(url, "hackage.haskell.org"):
+		return "hackage"
+	case strings.Contains(url, "hub.docker.com"):
+		return "docker"
+	case strings.Contains(url, "ftp.gnu.org"):
+		return "gnuftp"
+	case strings.Contains(url, "sourceforge.net"):
+		return "sourceforge"
+	default:
+		return ""
+	}
+}
+
+/****************************************************/
+// getPyPILatestVersion extracts a project name from a PyPI URL and queries
+// the PyPI JSON API for its current release
+/****************************************************/
+func getPyPILatestVersion(url string) (string, error) {
+	re := regexp.MustCompile(`pypi.org/project/([^/]+)`)
+	matches := re.FindStringSubmatch(url)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("invalid PyPI URL: %s", url)
+	}
+	project := matches[1]
+
+	apiURL := fmt.Sprintf("https://pypi.org/pypi/%s/json", project)
+	body, status, err := rateLimitedGet(apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if status != 200 {
+		return "", fmt.Errorf("PyPI API returned status %d", status)
+	}
+
+	var payload struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", err
+	}
+	if payload.Info.Version == "" {
+		return "", fmt.Errorf("no version found for %s", project)
+	}
+	return payload.Info.Version, nil
+}
+
+/****************************************************/
+// getNpmLatestVersion extracts a package name from an npm URL and queries
+// the npm registry for its "latest" dist-tag
+/****************************************************/
+func getNpmLatestVersion(url string) (string, error) {
+	re := regexp.MustCompile(`npmjs\.(?:com/package|org)/([^/?#]+)`)
+	matches := re.FindStringSubmatch(url)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("invalid npm URL: %s", url)
+	}
+	pkgName := matches[1]
+
+	apiURL := fmt.Sprintf("https://registry.npmjs.org/%s", pkgName)
+	body, status, err := rateLimitedGet(apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if status != 200 {
+		return "", fmt.Errorf("npm registry returned status %d", status)
+	}
+
+	var payload struct {
+		DistTags struct {
+			Latest string `json:"latest"`
+		} `json:"dist-tags"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", err
+	}
+	if payload.DistTags.Latest == "" {
+		return "", fmt.Errorf("no latest dist-tag found for %s", pkgName)
+	}
+	return payload.DistTags.Latest, nil
+}
+
+/****************************************************/
+// getCratesLatestVersion extracts a crate name from a crates.io URL and
+// queries the crates.io API for its max version
+/****************************************************/
+func getCratesLatestVersion(url string) (string, error) {
+	re := regexp.MustCompile(`crates\.io/crates/([^/?#]+)`)
+	matches := re.FindStringSubmatch(url)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("invalid crates.io URL: %s", url)
+	}
+	crateName := matches[1]
+
+	apiURL := fmt.Sprintf("https://crates.io/api/v1/crates/%s", crateName)
+	body, status, err := rateLimitedGet(apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if status != 200 {
+		return "", fmt.Errorf("crates.io API returned status %d", status)
+	}
+
+	var payload struct {
+		Crate struct {
+			MaxVersion string `json:"max_version"`
+		} `json:"crate"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", err
+	}
+	if payload.Crate.MaxVersion == "" {
+		return "", fmt.Errorf("no max_version found for %s", crateName)
+	}
+	return payload.Crate.MaxVersion, nil
+}
+
+/****************************************************/
+// getHackageLatestVersion extracts a package name from a Hackage URL and
+// queries Hackage's preferred-versions endpoint
+/****************************************************/
+func getHackageLatestVersion(url string) (string, error) {
+	re := regexp.MustCompile(`hackage\.haskell\.org/package/([^/?#]+)`)
+	matches := re.FindStringSubmatch(url)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("invalid Hackage URL: %s", url)
+	}
+	pkgName := matches[1]
+
+	apiURL := fmt.Sprintf("https://hackage.haskell.org/package/%s/preferred", pkgName)
+	body, status, err := rateLimitedGet(apiURL, func(req *http.Request) {
+		req.Header.Set("Accept", "application/json")
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if status != 200 {
+		return "", fmt.Errorf("Hackage API returned status %d", status)
+	}
+
+	var payload struct {
+		NormalVersion []string `json:"normal-version"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", err
+	}
+	if len(payload.NormalVersion) == 0 {
+		return "", fmt.Errorf("no versions found for %s", pkgName)
+	}
+
+	versions := []*semver.Version{}
+	for _, v := range payload.NormalVersion {
+		sv, err := semver.NewVersion(v)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, sv)
+	}
+	if len(versions) == 0 {
+		return payload.NormalVersion[0], nil
+	}
+
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	return versions[0].String(), nil
+}
+
+/****************************************************/
+// getDockerLatestTag extracts a repository name from a Docker Hub URL and
+// queries the Docker Registry v2 API's /tags/list, authenticating with a
+// short-lived anonymous pull token the same way `docker pull` does
+/****************************************************/
+func getDockerLatestTag(url string) (string, error) {
+	re := regexp.MustCompile(`hub\.docker\.com/r/([^/]+/[^/?#]+)`)
+	matches := re.FindStringSubmatch(url)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("invalid Docker Hub URL: %s", url)
+	}
+	repo := matches[1]
+
+	tokenURL := fmt.Sprintf("https://auth.docker.io/token?service=registry.docker.io&scope=repository:%s:pull", repo)
+	tokenBody, _, err := rateLimitedGet(tokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var tokenPayload struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(tokenBody, &tokenPayload); err != nil {
+		return "", err
+	}
+
+	tagsURL := fmt.Sprintf("https://registry-1.docker.io/v2/%s/tags/list", repo)
+	body, status, err := rateLimitedGet(tagsURL, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+tokenPayload.Token)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if status != 200 {
+		return "", fmt.Errorf("Docker registry returned status %d", status)
+	}
+
+	var payload struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", err
+	}
+	if len(payload.Tags) == 0 {
+		return "", fmt.Errorf("no tags found for %s", repo)
+	}
+
+	versions := []*semver.Version{}
+	for _, t := range payload.Tags {
+		v, err := semver.NewVersion(t)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no valid semver tags found for %s", repo)
+	}
+
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	return versions[0].String(), nil
+}
+
+/****************************************************/
+// getGNUFTPLatestVersion extracts a package name from a ftp.gnu.org URL and
+// picks the highest version out of that directory's listing
+/****************************************************/
+func getGNUFTPLatestVersion(url string) (string, error) {
+	re := regexp.MustCompile(`ftp\.gnu\.org/gnu/([^/?#]+)`)
+	matches := re.FindStringSubmatch(url)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("invalid GNU FTP URL: %s", url)
+	}
+	project := matches[1]
+
+	dirURL := fmt.Sprintf("https://ftp.gnu.org/gnu/%s/", project)
+	body, status, err := rateLimitedGet(dirURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if status != 200 {
+		return "", fmt.Errorf("GNU FTP directory listing returned status %d", status)
+	}
+
+	return latestVersionFromListing(body, project)
+}
+
+/****************************************************/
+// getSourceForgeLatestVersion extracts a project name from a SourceForge
+// URL and picks the highest version out of its file-release RSS feed
+/****************************************************/
+func getSourceForgeLatestVersion(url string) (string, error) {
+	re := regexp.MustCompile(`sourceforge\.net/projects/([^/?#]+)`)
+	matches := re.FindStringSubmatch(url)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("invalid SourceForge URL: %s", url)
+	}
+	project := matches[1]
+
+	rssURL := fmt.Sprintf("https://sourceforge.net/projects/%s/rss?path=/", project)
+	body, status, err := rateLimitedGet(rssURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if status != 200 {
+		return "", fmt.Errorf("SourceForge RSS feed returned status %d", status)
+	}
+
+	var feed struct {
+		Channel struct {
+			Items []struct {
+				Title string `xml:"title"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return "", err
+	}
+
+	versionRe := regexp.MustCompile(`(\d+(?:\.\d+)+)`)
+	versions := []*semver.Version{}
+	for _, item := range feed.Channel.Items {
+		m := versionRe.FindString(item.Title)
+		if m == "" {
+			continue
+		}
+		v, err := semver.NewVersion(m)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no versions found in RSS feed for %s", project)
+	}
+
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	return versions[0].String(), nil
+}
+
+/****************************************************/
+// latestVersionFromListing scans an Apache-style directory listing for
+// "<project>-X.Y.Z" filenames and returns the highest version found
+/****************************************************/
+func latestVersionFromListing(data []byte, project string) (string, error) {
+	nameRe := regexp.MustCompile(regexp.QuoteMeta(project) + `-(\d+(?:\.\d+)+)`)
+	matches := nameRe.FindAllStringSubmatch(string(data), -1)
+
+	versions := []*semver.Version{}
+	for _, m := range matches {
+		v, err := semver.NewVersion(m[1])
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no versions found in directory listing for %s", project)
+	}
+
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	return versions[0].String(), nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+const stateFilePath = "./state.json"
+
+// StateEntry tracks what we last saw for a single package, so repeated runs
+// only notify when something has actually changed.
+type StateEntry struct {
+	LastSeenVersion string    `json:"last_seen_version"`
+	LastChangedAt   time.Time `json:"last_changed_at"`
+	LastNotifiedAt  time.Time `json:"last_notified_at"`
+	FailureCount    int       `json:"failure_count"`
+	Warned          bool      `json:"warned"`
+}
+
+// StateStore is a JSON file keyed by "RepoName/PkgName" recording the last
+// known state of each tracked package across runs, so a package whose
+// version hasn't changed since the previous run stays silent.
+type StateStore struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]StateEntry
+}
+
+/****************************************************/
+// loadStateStore reads the state file at path, returning an empty store if
+// it doesn't exist yet
+/****************************************************/
+func loadStateStore(path string) (*StateStore, error) {
+	store := &StateStore{path: path, entries: map[string]StateEntry{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &store.entries); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+/****************************************************/
+// save writes the store back to disk as indented JSON
+/****************************************************/
+func (s *StateStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+func stateKey(repoName, pkgName string) string {
+	return repoName + "/" + pkgName
+}
+
+func (s *StateStore) get(repoName, pkgName string) (StateEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[stateKey(repoName, pkgName)]
+	return e, ok
+}
+
+func (s *StateStore) set(repoName, pkgName string, e StateEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[stateKey(repoName, pkgName)] = e
+}
+
+/****************************************************/
+// unchangedReport lists packages whose version hasn't changed in at least
+// the given number of days, for an "unchanged since" summary
+/****************************************************/
+func (s *StateStore) unchangedReport(days int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	threshold := time.Duration(days) * 24 * time.Hour
+	var lines []string
+	for key, e := range s.entries {
+		if e.LastChangedAt.IsZero() || time.Since(e.LastChangedAt) < threshold {
+			continue
+		}
+		lines = append(lines, key+" "+e.LastSeenVersion)
+	}
+	return lines
+}
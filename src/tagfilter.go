@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+/****************************************************/
+// tagToVersion applies a package's TagPattern, StripPrefix, and
+// VersionTransform rules to a single tag name, returning the resulting
+// version string and whether the tag survives TagPattern at all
+/****************************************************/
+func tagToVersion(tagName string, pkg *Package) (string, bool) {
+	version := tagName
+
+	if pkg.TagPattern != "" {
+		re, err := regexp.Compile(pkg.TagPattern)
+		if err != nil {
+			return "", false
+		}
+		matches := re.FindStringSubmatch(tagName)
+		if matches == nil {
+			return "", false
+		}
+		if len(matches) > 1 {
+			version = matches[1]
+		}
+	}
+
+	if pkg.StripPrefix != "" {
+		version = strings.TrimPrefix(version, pkg.StripPrefix)
+	}
+
+	if pkg.VersionTransform != "" {
+		var err error
+		version, err = applySedTransform(version, pkg.VersionTransform)
+		if err != nil {
+			return "", false
+		}
+	}
+
+	return version, true
+}
+
+/****************************************************/
+// applySedTransform applies a sed-style "s/pattern/replacement/" expression
+// to s, in the spirit of Renovate's versioning transform rules. Every match
+// of pattern is replaced, as with sed's g flag; any text after the
+// replacement (such as flags) is ignored.
+/****************************************************/
+func applySedTransform(s, transform string) (string, error) {
+	if !strings.HasPrefix(transform, "s/") {
+		return "", fmt.Errorf("unsupported VersionTransform %q, expected s/pattern/replacement/", transform)
+	}
+
+	parts := strings.Split(transform[2:], "/")
+	if len(parts) < 2 {
+		return "", fmt.Errorf("malformed VersionTransform %q, expected s/pattern/replacement/", transform)
+	}
+
+	pattern, replacement := parts[0], parts[1]
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(s, replacement), nil
+}
+
+/****************************************************/
+// selectLatestTag applies pkg's tag filtering rules to tagNames, skips
+// prereleases unless IncludePrerelease is set, and returns the highest
+// remaining semver version — matching the conventions Go's module resolver
+// and Renovate use
+/****************************************************/
+func selectLatestTag(tagNames []string, pkg *Package) (string, error) {
+	versions := []*semver.Version{}
+	for _, tagName := range tagNames {
+		versionStr, ok := tagToVersion(tagName, pkg)
+		if !ok {
+			continue
+		}
+
+		v, err := semver.NewVersion(versionStr)
+		if err != nil {
+			continue
+		}
+		if v.Prerelease() != "" && !pkg.IncludePrerelease {
+			continue
+		}
+		versions = append(versions, v)
+	}
+
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no matching semver tags found")
+	}
+
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	return versions[0].String(), nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import "testing"
+
+func TestApplySedTransform(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     string
+		transform string
+		want      string
+		wantErr   bool
+	}{
+		{"simple replace", "1_2_3", "s/_/./", "1.2.3", false},
+		{"trailing flag segment ignored", "1_2_3", "s/_/./g", "1.2.3", false},
+		{"no match leaves input untouched", "1.2.3", "s/_/./", "1.2.3", false},
+		{"missing s prefix", "1.2.3", "1.2.3", "", true},
+		{"malformed expression", "1.2.3", "s/only-one-part", "", true},
+		{"invalid regex pattern", "1.2.3", "s/(/./", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := applySedTransform(tc.input, tc.transform)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("applySedTransform(%q, %q) = %q, nil; want error", tc.input, tc.transform, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applySedTransform(%q, %q) returned unexpected error: %v", tc.input, tc.transform, err)
+			}
+			if got != tc.want {
+				t.Fatalf("applySedTransform(%q, %q) = %q, want %q", tc.input, tc.transform, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSelectLatestTag(t *testing.T) {
+	t.Run("plain semver tags", func(t *testing.T) {
+		got, err := selectLatestTag([]string{"v1.0.0", "v1.2.0", "v1.1.0"}, &Package{StripPrefix: "v"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "1.2.0" {
+			t.Fatalf("got %q, want %q", got, "1.2.0")
+		}
+	})
+
+	t.Run("prereleases excluded by default", func(t *testing.T) {
+		got, err := selectLatestTag([]string{"v1.0.0", "v1.1.0-rc1"}, &Package{StripPrefix: "v"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "1.0.0" {
+			t.Fatalf("got %q, want %q", got, "1.0.0")
+		}
+	})
+
+	t.Run("prereleases included when requested", func(t *testing.T) {
+		got, err := selectLatestTag([]string{"v1.0.0", "v1.1.0-rc1"}, &Package{StripPrefix: "v", IncludePrerelease: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "1.1.0-rc1" {
+			t.Fatalf("got %q, want %q", got, "1.1.0-rc1")
+		}
+	})
+
+	t.Run("tag pattern restricts candidates", func(t *testing.T) {
+		tags := []string{"app-v1.0.0", "lib-v9.9.9", "app-v1.2.0"}
+		pkg := &Package{TagPattern: `^app-v(\d+\.\d+\.\d+)$`}
+		got, err := selectLatestTag(tags, pkg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "1.2.0" {
+			t.Fatalf("got %q, want %q", got, "1.2.0")
+		}
+	})
+
+	t.Run("no matching tags errors", func(t *testing.T) {
+		_, err := selectLatestTag([]string{"not-a-version"}, &Package{})
+		if err == nil {
+			t.Fatalf("expected error for no matching tags")
+		}
+	})
+}